@@ -0,0 +1,219 @@
+package dsdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CollectionExpect optionally asserts on a CollectionRequest's result: an
+// HTTP status code, a JSONPath-matched field on the response data, or both.
+type CollectionExpect struct {
+	Status   int         `json:"status,omitempty"`
+	JSONPath string      `json:"jsonpath,omitempty"`
+	Equals   interface{} `json:"equals,omitempty"`
+}
+
+// CollectionRequest is a single Datera API call in a Collection. Body
+// accepts either of the two forms parseParams understands: a flat array of
+// "key=value" strings, or a single nested JSON object.
+type CollectionRequest struct {
+	Name     string            `json:"name"`
+	Method   string            `json:"method"`
+	Endpoint string            `json:"endpoint"`
+	Headers  map[string]string `json:"headers,omitempty"`
+	QParams  []string          `json:"qparams,omitempty"`
+	Body     json.RawMessage   `json:"body,omitempty"`
+	Expect   *CollectionExpect `json:"expect,omitempty"`
+}
+
+// Collection is a Hoppscotch-style tree of folders and requests that can be
+// loaded with ReadCollection and executed with RunCollection.
+type Collection struct {
+	Name     string               `json:"name"`
+	Folders  []*Collection        `json:"folders,omitempty"`
+	Requests []*CollectionRequest `json:"requests,omitempty"`
+}
+
+// RequestResult is the outcome of a single CollectionRequest.
+type RequestResult struct {
+	Name     string
+	ReqUUID  string
+	Method   string
+	Endpoint string
+	Status   int
+	Latency  float64
+	Response json.RawMessage
+	Err      error
+}
+
+// ReadCollection loads a Collection from a JSON file on disk.
+func ReadCollection(path string) (*Collection, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var c Collection
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// RunCollection executes every request in coll, depth-first through its
+// folders, against r. It keeps running after a request fails so the caller
+// gets a result for every request; check each RequestResult.Err rather than
+// the returned error, which only reports why the walk itself stopped (it
+// doesn't, today, but is reserved for that).
+func (r *APIConnection) RunCollection(coll *Collection) ([]RequestResult, error) {
+	var results []RequestResult
+	r.runCollection(coll, &results)
+	return results, nil
+}
+
+func (r *APIConnection) runCollection(coll *Collection, results *[]RequestResult) {
+	for _, req := range coll.Requests {
+		*results = append(*results, r.runCollectionRequest(req))
+	}
+	for _, folder := range coll.Folders {
+		r.runCollection(folder, results)
+	}
+}
+
+func (r *APIConnection) runCollectionRequest(req *CollectionRequest) RequestResult {
+	reqUUID, _ := NewUUID()
+	res := RequestResult{
+		Name:     req.Name,
+		ReqUUID:  reqUUID,
+		Method:   req.Method,
+		Endpoint: req.Endpoint,
+	}
+	bodyArgs, err := collectionBodyArgs(req.Body)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	var resp []byte
+	var status int
+	start := time.Now()
+	switch strings.ToLower(req.Method) {
+	case "get":
+		resp, status, err = r.doRequestStatusHeaders(context.Background(), "get", req.Endpoint, nil, req.QParams, false, req.Headers)
+	case "put", "post", "delete":
+		var params map[string]interface{}
+		params, err = parseParams(bodyArgs...)
+		if err == nil {
+			var body []byte
+			body, err = json.Marshal(params)
+			if err == nil {
+				resp, status, err = r.doRequestStatusHeaders(context.Background(), strings.ToLower(req.Method), req.Endpoint, body, nil, false, req.Headers)
+			}
+		}
+	default:
+		err = fmt.Errorf("collection request %q: unsupported method %q", req.Name, req.Method)
+	}
+	res.Latency = time.Since(start).Seconds()
+	res.Status = status
+	res.Err = err
+	if err == nil {
+		if data, _, derr := getData(resp); derr == nil {
+			res.Response = data
+		} else {
+			res.Response = resp
+		}
+	}
+	if req.Expect != nil {
+		if eerr := checkExpect(req.Expect, res); eerr != nil {
+			res.Err = eerr
+		}
+	}
+	return res
+}
+
+// collectionBodyArgs converts a request's JSON body into the bodyp form
+// Put/Post/Delete expect: either a flat slice of "key=value" strings, or a
+// single map[string]interface{}.
+func collectionBodyArgs(raw json.RawMessage) ([]interface{}, error) {
+	if len(raw) == 0 {
+		return nil, nil
+	}
+	var flat []string
+	if err := json.Unmarshal(raw, &flat); err == nil {
+		args := make([]interface{}, len(flat))
+		for i, s := range flat {
+			args[i] = s
+		}
+		return args, nil
+	}
+	var nested map[string]interface{}
+	if err := json.Unmarshal(raw, &nested); err == nil {
+		return []interface{}{nested}, nil
+	}
+	return nil, fmt.Errorf("collection request body must be a []string or map[string]interface{}: %s", raw)
+}
+
+// checkExpect validates a CollectionExpect against a request's result.
+func checkExpect(exp *CollectionExpect, res RequestResult) error {
+	if exp.Status != 0 && exp.Status != res.Status {
+		return fmt.Errorf("expected status %d, got %d", exp.Status, res.Status)
+	}
+	if exp.JSONPath != "" {
+		v, err := lookupJSONPath(res.Response, exp.JSONPath)
+		if err != nil {
+			return err
+		}
+		if exp.Equals != nil && !reflect.DeepEqual(v, exp.Equals) {
+			return fmt.Errorf("jsonpath %q: expected %v, got %v", exp.JSONPath, exp.Equals, v)
+		}
+	}
+	return nil
+}
+
+// lookupJSONPath resolves a dot-separated path (with optional "[n]" array
+// indexing, e.g. "storage_instances[0].name") against a JSON document.
+func lookupJSONPath(data json.RawMessage, path string) (interface{}, error) {
+	var cur interface{}
+	if err := json.Unmarshal(data, &cur); err != nil {
+		return nil, err
+	}
+	if path == "" {
+		return cur, nil
+	}
+	for _, part := range strings.Split(path, ".") {
+		key, idx, hasIdx := splitIndex(part)
+		m, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("jsonpath %q: %q is not an object", path, part)
+		}
+		v, ok := m[key]
+		if !ok {
+			return nil, fmt.Errorf("jsonpath %q: key %q not found", path, key)
+		}
+		if hasIdx {
+			arr, ok := v.([]interface{})
+			if !ok || idx >= len(arr) {
+				return nil, fmt.Errorf("jsonpath %q: %q is not a large-enough array", path, key)
+			}
+			v = arr[idx]
+		}
+		cur = v
+	}
+	return cur, nil
+}
+
+func splitIndex(part string) (string, int, bool) {
+	open := strings.Index(part, "[")
+	if open == -1 || !strings.HasSuffix(part, "]") {
+		return part, 0, false
+	}
+	idx, err := strconv.Atoi(part[open+1 : len(part)-1])
+	if err != nil {
+		return part, 0, false
+	}
+	return part[:open], idx, true
+}
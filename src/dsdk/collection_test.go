@@ -0,0 +1,76 @@
+package dsdk
+
+import (
+	"encoding/json"
+	"sync"
+	"testing"
+)
+
+func TestLookupJSONPath(t *testing.T) {
+	doc := json.RawMessage(`{
+		"name": "my-ai",
+		"storage_instances": [
+			{"name": "si-1", "volumes": [{"name": "vol-1"}]}
+		]
+	}`)
+
+	cases := []struct {
+		path string
+		want interface{}
+	}{
+		{"name", "my-ai"},
+		{"storage_instances[0].name", "si-1"},
+		{"storage_instances[0].volumes[0].name", "vol-1"},
+	}
+	for _, c := range cases {
+		got, err := lookupJSONPath(doc, c.path)
+		if err != nil {
+			t.Errorf("lookupJSONPath(%q): %s", c.path, err)
+			continue
+		}
+		if got != c.want {
+			t.Errorf("lookupJSONPath(%q) = %v, want %v", c.path, got, c.want)
+		}
+	}
+}
+
+func TestLookupJSONPathErrors(t *testing.T) {
+	doc := json.RawMessage(`{"storage_instances": [{"name": "si-1"}]}`)
+
+	cases := []string{
+		"missing",
+		"storage_instances[5].name",
+		"storage_instances.name",
+	}
+	for _, path := range cases {
+		if _, err := lookupJSONPath(doc, path); err == nil {
+			t.Errorf("lookupJSONPath(%q): expected an error, got nil", path)
+		}
+	}
+}
+
+// TestRunCollectionConcurrentDoesNotRaceHeaders runs a Collection with
+// per-request headers from eight goroutines sharing one connection under
+// -race: runCollectionRequest must not mutate APIConnection.Headers to honor
+// them, since that field is read by every other in-flight call too.
+func TestRunCollectionConcurrentDoesNotRaceHeaders(t *testing.T) {
+	conn := newTestConnection(t, &FakeTransport{
+		Responses: []FakeResponse{{Status: 200, Body: []byte(`{}`)}},
+	})
+	coll := &Collection{
+		Requests: []*CollectionRequest{
+			{Name: "r1", Method: "get", Endpoint: "app_instances", Headers: map[string]string{"X-Req": "1"}},
+			{Name: "r2", Method: "get", Endpoint: "app_instances", Headers: map[string]string{"X-Req": "2"}},
+		},
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			conn.RunCollection(coll)
+		}()
+	}
+	wg.Wait()
+}
@@ -2,16 +2,24 @@ package dsdk
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
+	"github.com/Datera/go-sdk/src/dsdk/metrics"
 	log "github.com/Sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 	"io"
 	"io/ioutil"
+	"math/rand"
+	"net"
 	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"text/template"
 	"time"
 )
@@ -19,33 +27,22 @@ import (
 const (
 	connTemplate    = "http://{{.hostname}}:{{.port}}/v{{.version}}/{{.endpoint}}"
 	secConnTemplate = "https://{{.hostname}}:{{.port}}/v{{.version}}/{{.endpoint}}"
-	permDeniedError = "PermissionDeniedError"
 	USetToken       = ""
 )
 
-var (
-	httpErrors = map[int]bool{
-		400: true,
-		401: true,
-		422: true,
-		500: true}
-
-	Retry = fmt.Errorf("Retry")
-)
-
 type IAPIConnection interface {
 	Post(string, ...interface{}) ([]byte, error)
 	Get(string, ...string) ([]byte, error)
 	Put(string, bool, ...interface{}) ([]byte, error)
 	Delete(string, ...interface{}) ([]byte, error)
+	PostCtx(context.Context, string, ...interface{}) ([]byte, error)
+	GetCtx(context.Context, string, ...string) ([]byte, error)
+	PutCtx(context.Context, string, bool, ...interface{}) ([]byte, error)
+	DeleteCtx(context.Context, string, ...interface{}) ([]byte, error)
 	Login() error
 	UpdateHeaders(...string) error
 }
 
-type ConnectionPool struct {
-	conns []*APIConnection
-}
-
 type APIConnection struct {
 	Mutex      *sync.Mutex
 	Method     string
@@ -61,6 +58,41 @@ type APIConnection struct {
 	Client     *http.Client
 	APIToken   string
 	Tenant     string
+	// RetryPolicy controls how doRequest retries a failed call. A nil
+	// RetryPolicy falls back to defaultRetryPolicy().
+	RetryPolicy *RetryPolicy
+	// Metrics, if set, records Prometheus metrics for every call.
+	Metrics *metrics.Collector
+	// TracerProvider, if set, opens an OpenTelemetry span for every call.
+	TracerProvider trace.TracerProvider
+	// Transport performs the actual wire exchange. A nil Transport falls
+	// back to an HTTPTransport wrapping Client.
+	Transport Transport
+
+	// InFlight tracks the number of requests currently outstanding on this
+	// connection so a ConnectionPool can prefer the least-busy connection.
+	InFlight int32
+	// FailureCount tracks consecutive quarantine-worthy errors seen by this
+	// connection. It resets to zero on any successful request.
+	FailureCount int32
+	// Quarantined is set (1) once FailureCount reaches quarantineThreshold,
+	// removing this connection from a pool's rotation until it is
+	// revalidated.
+	Quarantined int32
+	// QuarantinedAt records when Quarantined was set so the pool's
+	// revalidator can back off before retrying a login.
+	QuarantinedAt time.Time
+
+	// deadlineMu guards readDeadline, writeDeadline, and deadlineCh.
+	deadlineMu sync.Mutex
+	// readDeadline/writeDeadline, when non-zero, bound the context derived
+	// for each call by SetReadDeadline/SetWriteDeadline.
+	readDeadline  time.Time
+	writeDeadline time.Time
+	// deadlineCh is closed and replaced every time either deadline changes,
+	// so in-flight calls started under the old deadline are cancelled
+	// rather than left to run to the new, possibly later, one.
+	deadlineCh chan struct{}
 }
 
 type ReturnLogin struct {
@@ -101,16 +133,18 @@ func NewAPIConnection(hostname, port, username, password, apiVersion, tenant, ti
 		h[p] = v
 	}
 	c := APIConnection{
-		Mutex:      &sync.Mutex{},
-		Hostname:   hostname,
-		Port:       port,
-		Username:   username,
-		Password:   password,
-		Tenant:     tenant,
-		Headers:    h,
-		APIVersion: apiVersion,
-		Secure:     secure,
-		Client:     &http.Client{Timeout: t},
+		Mutex:       &sync.Mutex{},
+		Hostname:    hostname,
+		Port:        port,
+		Username:    username,
+		Password:    password,
+		Tenant:      tenant,
+		Headers:     h,
+		APIVersion:  apiVersion,
+		Secure:      secure,
+		Client:      &http.Client{Timeout: t},
+		deadlineCh:  make(chan struct{}),
+		RetryPolicy: defaultRetryPolicy(),
 	}
 	c.UpdateHeaders(fmt.Sprintf("tenant=%s", tenant))
 	log.Debugf("New API connection: %#v", c)
@@ -192,8 +226,328 @@ func (r *APIConnection) prepConn() (string, error) {
 	return conn, err
 }
 
-func (r *APIConnection) doRequest(method, endpoint string, body []byte, qparams []string, sensitive bool, retry bool) ([]byte, error) {
+// SetReadDeadline sets the read deadline. Each call is bounded as a whole by
+// the earlier of the read and write deadlines. It takes effect on the next
+// call; if t differs from the current read deadline, every call already in
+// flight is cancelled.
+func (r *APIConnection) SetReadDeadline(t time.Time) error {
+	r.deadlineMu.Lock()
+	defer r.deadlineMu.Unlock()
+	if r.readDeadline.Equal(t) {
+		return nil
+	}
+	r.readDeadline = t
+	close(r.deadlineCh)
+	r.deadlineCh = make(chan struct{})
+	return nil
+}
+
+// SetWriteDeadline sets the write deadline. Each call is bounded as a whole
+// by the earlier of the read and write deadlines. It takes effect on the next
+// call; if t differs from the current write deadline, every call already in
+// flight is cancelled.
+func (r *APIConnection) SetWriteDeadline(t time.Time) error {
+	r.deadlineMu.Lock()
+	defer r.deadlineMu.Unlock()
+	if r.writeDeadline.Equal(t) {
+		return nil
+	}
+	r.writeDeadline = t
+	close(r.deadlineCh)
+	r.deadlineCh = make(chan struct{})
+	return nil
+}
+
+// earliestNonZero returns whichever of a, b is non-zero and sooner, or the
+// zero Time if both are zero.
+func earliestNonZero(a, b time.Time) time.Time {
+	if a.IsZero() {
+		return b
+	}
+	if b.IsZero() {
+		return a
+	}
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
+
+// deadlineContext derives a context from parent that also honors the
+// connection's read/write deadlines and is cancelled immediately if either
+// deadline is changed mid-call via SetReadDeadline/SetWriteDeadline.
+func (r *APIConnection) deadlineContext(parent context.Context) (context.Context, context.CancelFunc) {
+	r.deadlineMu.Lock()
+	deadline := earliestNonZero(r.readDeadline, r.writeDeadline)
+	deadlineCh := r.deadlineCh
+	r.deadlineMu.Unlock()
+
+	var ctx context.Context
+	var cancel context.CancelFunc
+	if deadline.IsZero() {
+		ctx, cancel = context.WithCancel(parent)
+	} else {
+		ctx, cancel = context.WithDeadline(parent, deadline)
+	}
+	stop := make(chan struct{})
+	go func() {
+		select {
+		case <-deadlineCh:
+			cancel()
+		case <-stop:
+		}
+	}()
+	return ctx, func() {
+		close(stop)
+		cancel()
+	}
+}
+
+// readAllCtx reads body to completion, but abandons the read and returns
+// ctx.Err() as soon as ctx is done, since ioutil.ReadAll itself cannot be
+// cancelled mid-read.
+func readAllCtx(ctx context.Context, body io.ReadCloser) ([]byte, error) {
+	type result struct {
+		b   []byte
+		err error
+	}
+	ch := make(chan result, 1)
+	go func() {
+		b, err := ioutil.ReadAll(body)
+		ch <- result{b, err}
+	}()
+	select {
+	case <-ctx.Done():
+		return []byte(""), ctx.Err()
+	case res := <-ch:
+		return res.b, res.err
+	}
+}
+
+// defaultRetryPolicy is used by any APIConnection whose RetryPolicy is nil.
+func defaultRetryPolicy() *RetryPolicy {
+	return &RetryPolicy{
+		MaxAttempts:    3,
+		InitialBackoff: 250 * time.Millisecond,
+		MaxBackoff:     5 * time.Second,
+		RetryableStatuses: map[int]bool{
+			http.StatusUnauthorized:       true,
+			http.StatusTooManyRequests:    true,
+			http.StatusBadGateway:         true,
+			http.StatusServiceUnavailable: true,
+			http.StatusGatewayTimeout:     true,
+		},
+		RetryableErrors: func(err error) bool {
+			_, ok := err.(net.Error)
+			return ok
+		},
+	}
+}
+
+// RetryPolicy controls how doRequest retries a failed call: how many times
+// to try, how long to wait between attempts, and which statuses/errors are
+// worth retrying in the first place.
+type RetryPolicy struct {
+	MaxAttempts       int
+	InitialBackoff    time.Duration
+	MaxBackoff        time.Duration
+	RetryableStatuses map[int]bool
+	RetryableErrors   func(error) bool
+}
+
+// shouldRetry reports whether err is worth another attempt under p.
+func (p *RetryPolicy) shouldRetry(err error) bool {
+	if err == nil {
+		return false
+	}
+	if se, ok := err.(*statusError); ok {
+		return p.RetryableStatuses[se.Code]
+	}
+	if p.RetryableErrors != nil {
+		return p.RetryableErrors(err)
+	}
+	return false
+}
+
+// backoff returns a full-jitter exponential backoff duration for the given
+// (1-indexed) attempt number: a random wait between 0 and
+// min(InitialBackoff*2^(attempt-1), MaxBackoff).
+func (p *RetryPolicy) backoff(attempt int) time.Duration {
+	ceiling := p.InitialBackoff << uint(attempt-1)
+	if ceiling <= 0 || ceiling > p.MaxBackoff {
+		ceiling = p.MaxBackoff
+	}
+	if ceiling <= 0 {
+		return 0
+	}
+	return time.Duration(rand.Int63n(int64(ceiling)))
+}
+
+// statusError wraps an HTTP response with a status of 400 or above. Its message matches
+// resp.Status (e.g. "404 Not Found") for compatibility with callers that
+// parsed the status code back out of the old httpErrors-based errors.
+type statusError struct {
+	Code   int
+	Status string
+}
+
+func (e *statusError) Error() string { return e.Status }
+
+func (r *APIConnection) doRequest(ctx context.Context, method, endpoint string, body []byte, qparams []string, sensitive bool) ([]byte, error) {
+	rbody, _, err := r.doRequestStatus(ctx, method, endpoint, body, qparams, sensitive)
+	return rbody, err
+}
+
+// doRequestStatus is doRequest, but also returns the actual HTTP status code
+// observed on the wire: 0 if the call never got a response (a transport
+// error, or a timeout between retries), and the real code otherwise, even on
+// success, so callers that care about the exact 2xx (201 vs 204, say) don't
+// have to infer it from a nil error.
+func (r *APIConnection) doRequestStatus(ctx context.Context, method, endpoint string, body []byte, qparams []string, sensitive bool) ([]byte, int, error) {
+	return r.doRequestStatusHeaders(ctx, method, endpoint, body, qparams, sensitive, nil)
+}
+
+// doRequestStatusHeaders is doRequestStatus, but merges extraHeaders over
+// r.Headers for the duration of this call only, without ever writing them
+// into r.Headers. That keeps a per-request header override (e.g. from
+// RunCollection) from leaking into concurrent calls on the same connection.
+func (r *APIConnection) doRequestStatusHeaders(ctx context.Context, method, endpoint string, body []byte, qparams []string, sensitive bool, extraHeaders map[string]string) ([]byte, int, error) {
 	r.Mutex.Lock()
+	ctx, cancel := r.deadlineContext(ctx)
+	defer cancel()
+
+	policy := r.RetryPolicy
+	if policy == nil {
+		policy = defaultRetryPolicy()
+	}
+
+	endpointTpl := endpointTemplate(endpoint)
+	if r.Metrics != nil {
+		r.Metrics.RequestsInFlight.Inc()
+		defer r.Metrics.RequestsInFlight.Dec()
+		r.Metrics.RequestBodyBytes.WithLabelValues(method, endpointTpl).Observe(float64(len(body)))
+	}
+	ctx, span := r.startSpan(ctx, method, endpointTpl)
+	start := time.Now()
+
+	var rbody []byte
+	var reqUUID string
+	var err error
+	var status int
+attempts:
+	for attempt := 1; ; attempt++ {
+		var retryAfter time.Duration
+		rbody, status, retryAfter, reqUUID, err = r.attemptRequest(ctx, method, endpoint, body, qparams, sensitive, extraHeaders)
+		if attempt >= policy.MaxAttempts || !policy.shouldRetry(err) {
+			break
+		}
+		if r.Metrics != nil {
+			r.Metrics.RetriesTotal.WithLabelValues(method, endpointTpl).Inc()
+		}
+		// Only a 401 means the token itself is bad; only then is it worth
+		// spending a Login() before the next attempt.
+		if se, ok := err.(*statusError); ok && se.Code == http.StatusUnauthorized && ctx.Err() == nil {
+			r.Mutex.Unlock()
+			r.APIToken = USetToken
+			r.Login()
+			r.Mutex.Lock()
+		}
+		wait := retryAfter
+		if wait == 0 {
+			wait = policy.backoff(attempt)
+		}
+		r.Mutex.Unlock()
+		select {
+		case <-ctx.Done():
+			r.Mutex.Lock()
+			err = ctx.Err()
+			status = 0
+			break attempts
+		case <-time.After(wait):
+		}
+		r.Mutex.Lock()
+	}
+	r.Mutex.Unlock()
+	r.trackResult(err)
+
+	if r.Metrics != nil {
+		r.Metrics.RequestDuration.WithLabelValues(method, endpointTpl, strconv.Itoa(status)).Observe(time.Since(start).Seconds())
+		r.Metrics.ResponseBodyBytes.WithLabelValues(method, endpointTpl).Observe(float64(len(rbody)))
+	}
+	r.endSpan(span, status, reqUUID, err)
+	return rbody, status, err
+}
+
+// startSpan opens a span named "Datera.<Method> <endpoint>" if a
+// TracerProvider is configured; otherwise it's a no-op and ctx is returned
+// unchanged.
+func (r *APIConnection) startSpan(ctx context.Context, method, endpointTpl string) (context.Context, trace.Span) {
+	if r.TracerProvider == nil {
+		return ctx, trace.SpanFromContext(context.Background())
+	}
+	tracer := r.TracerProvider.Tracer("dsdk")
+	ctx, span := tracer.Start(ctx, fmt.Sprintf("Datera.%s %s", strings.ToUpper(method), endpointTpl))
+	span.SetAttributes(
+		attribute.String("datera.tenant", r.Tenant),
+		attribute.String("datera.api_version", r.APIVersion),
+	)
+	return ctx, span
+}
+
+// endSpan records the outcome of a call on span and closes it. It's safe to
+// call on the no-op span startSpan returns when no TracerProvider is set.
+func (r *APIConnection) endSpan(span trace.Span, status int, reqUUID string, err error) {
+	span.SetAttributes(
+		attribute.Int("http.status_code", status),
+		attribute.String("datera.req_uuid", reqUUID),
+	)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// knownCollections maps a Datera API collection name to the placeholder
+// used for the opaque name/ID segment that follows it, so endpointTemplate
+// can keep metric and span label cardinality bounded.
+var knownCollections = map[string]string{
+	"app_instances":           "name",
+	"storage_instances":       "si",
+	"volumes":                 "volume",
+	"snapshots":               "snapshot",
+	"initiators":              "initiator",
+	"initiator_groups":        "ig",
+	"access_network_ip_pools": "pool",
+	"policies":                "policy",
+}
+
+// endpointTemplate strips the trailing opaque segment after any known
+// collection name in endpoint, e.g.
+// "app_instances/my-ai/storage_instances/si-1" becomes
+// "/app_instances/{name}/storage_instances/{si}".
+func endpointTemplate(endpoint string) string {
+	segs := strings.Split(strings.Trim(endpoint, "/"), "/")
+	out := make([]string, 0, len(segs))
+	for i := 0; i < len(segs); i++ {
+		out = append(out, segs[i])
+		if placeholder, ok := knownCollections[segs[i]]; ok && i+1 < len(segs) {
+			out = append(out, "{"+placeholder+"}")
+			i++
+		}
+	}
+	return "/" + strings.Join(out, "/")
+}
+
+// attemptRequest performs a single HTTP round trip: build the request, send
+// it, read the body (aborting early if ctx is done), and classify the
+// response. It does not retry or log in; doRequest's loop handles that. The
+// returned status is the real HTTP status code observed (0 if the call never
+// got a response), and the returned duration is a Retry-After hint from a
+// 429/503 response, or 0. extraHeaders, if non-nil, are merged over
+// r.Headers for this attempt only and are never written into r.Headers, so
+// they don't leak into other calls on the same connection.
+func (r *APIConnection) attemptRequest(ctx context.Context, method, endpoint string, body []byte, qparams []string, sensitive bool, extraHeaders map[string]string) ([]byte, int, time.Duration, string, error) {
 	// Handle method
 	var m string
 	switch strings.ToLower(method) {
@@ -216,31 +570,27 @@ func (r *APIConnection) doRequest(method, endpoint string, body []byte, qparams
 	// prepConn handles header addition, url construction and query params
 	conn, err := r.prepConn()
 	if err != nil {
-		return []byte(""), err
-	}
-	var b io.Reader
-	if body == nil {
-		b = nil
-	} else {
-		b = bytes.NewReader(body)
-	}
-	req, err := http.NewRequest(r.Method, conn, b)
-	for h, v := range r.Headers {
-		req.Header.Set(h, v)
-	}
-	if err != nil {
-		return []byte(""), err
+		return []byte(""), 0, 0, "", err
 	}
 	reqUUID, err := NewUUID()
 	if err != nil {
-		return []byte(""), err
+		return []byte(""), 0, 0, "", err
 	}
 	// Obscure sensitive information
-	var logb io.Reader
+	logb := body
 	if sensitive {
-		logb = bytes.NewReader([]byte("************"))
-	} else {
-		logb = b
+		logb = []byte("************")
+	}
+	reqHeaders := r.Headers
+	if len(extraHeaders) > 0 {
+		merged := make(map[string]string, len(r.Headers)+len(extraHeaders))
+		for k, v := range r.Headers {
+			merged[k] = v
+		}
+		for k, v := range extraHeaders {
+			merged[k] = v
+		}
+		reqHeaders = merged
 	}
 	log.Debugf(strings.Join([]string{
 		"\nDatera Trace ID: %s",
@@ -254,47 +604,100 @@ func (r *APIConnection) doRequest(method, endpoint string, body []byte, qparams
 		conn,
 		r.Method,
 		logb,
-		r.Headers)
-	start1 := time.Now()
-	resp, err := r.Client.Do(req)
-	if err != nil {
-		return []byte(""), err
-	}
-	defer resp.Body.Close()
-	dur := time.Since(start1).Seconds()
-	start2 := time.Now()
-	rbody, err := ioutil.ReadAll(resp.Body)
-	dur2 := time.Since(start2).Seconds()
+		reqHeaders)
+	transport := r.Transport
+	if transport == nil {
+		transport = NewHTTPTransport(r.Client)
+	}
+	start := time.Now()
+	status, headers, rbody, err := transport.RoundTrip(ctx, r.Method, conn, reqHeaders, body)
+	dur := time.Since(start).Seconds()
 	if err != nil {
-		return []byte(""), err
+		return []byte(""), 0, 0, reqUUID, err
 	}
 	log.Debugf(strings.Join([]string{
 		// "\nDatera Trace ID: %s",
 		"Datera Response ID: %s",
-		"Datera Response Status: %s",
+		"Datera Response Status: %d",
 		"Datera Response Payload: %s",
 		"Datera Response Headers: %s"}, "\n"),
 		// nil,
 		reqUUID,
-		resp.Status,
+		status,
 		rbody,
-		resp.Header)
-	log.Debugf("\nRequest %s Duration Response: %.2fs", reqUUID, dur)
-	log.Debugf("\nRequest %s Duration Read: %.2fs", reqUUID, dur2)
-	err = handleBadResponse(resp)
-	// Retry if we need to login, but only once
-	if err == Retry && !retry {
+		headers)
+	log.Debugf("\nRequest %s Duration: %.2fs", reqUUID, dur)
+	return rbody, status, retryAfterDuration(status, headers), reqUUID, handleBadResponse(status)
+}
+
+// retryAfterDuration parses a Retry-After header off a 429/503 response,
+// supporting both the delta-seconds and HTTP-date forms.
+func retryAfterDuration(status int, headers http.Header) time.Duration {
+	if status != http.StatusTooManyRequests && status != http.StatusServiceUnavailable {
+		return 0
+	}
+	h := headers.Get("Retry-After")
+	if h == "" {
+		return 0
+	}
+	if secs, err := strconv.Atoi(h); err == nil {
+		return time.Duration(secs) * time.Second
+	}
+	if t, err := http.ParseTime(h); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d
+		}
+	}
+	return 0
+}
+
+// quarantineThreshold is the number of consecutive quarantine-worthy errors
+// (5xx responses or transport errors) after which a ConnectionPool pulls a
+// connection out of rotation.
+const quarantineThreshold = 3
+
+// trackResult updates the connection's failure bookkeeping so a
+// ConnectionPool can decide whether to quarantine it. A nil error, or an
+// error that isn't quarantine-worthy (e.g. a 400/422 client error), resets
+// the failure streak.
+func (r *APIConnection) trackResult(err error) {
+	if !isQuarantineWorthy(err) {
+		atomic.StoreInt32(&r.FailureCount, 0)
+		return
+	}
+	if atomic.AddInt32(&r.FailureCount, 1) < quarantineThreshold {
+		return
+	}
+	if atomic.CompareAndSwapInt32(&r.Quarantined, 0, 1) {
+		r.Mutex.Lock()
+		r.QuarantinedAt = time.Now()
 		r.Mutex.Unlock()
-		r.APIToken = USetToken
-		r.Login()
-		r.doRequest(method, endpoint, body, qparams, sensitive, true)
+		log.Warnf("Quarantining connection %s:%s after %d consecutive errors: %s",
+			r.Hostname, r.Port, quarantineThreshold, err)
 	}
-	r.Mutex.Unlock()
-	return rbody, err
+}
+
+// isQuarantineWorthy reports whether err is a 5xx response or a
+// transport-level failure, as opposed to an ordinary 4xx client error.
+func isQuarantineWorthy(err error) bool {
+	if err == nil {
+		return false
+	}
+	if se, ok := err.(*statusError); ok {
+		return se.Code >= 500
+	}
+	_, ok := err.(net.Error)
+	return ok
 }
 
 func (r *APIConnection) Get(endpoint string, qparams ...string) ([]byte, error) {
-	return r.doRequest("get", endpoint, nil, qparams, false, false)
+	return r.GetCtx(context.Background(), endpoint, qparams...)
+}
+
+// GetCtx is Get, but aborts the request (and the read of its response body)
+// as soon as ctx is done.
+func (r *APIConnection) GetCtx(ctx context.Context, endpoint string, qparams ...string) ([]byte, error) {
+	return r.doRequest(ctx, "get", endpoint, nil, qparams, false)
 }
 
 // bodyp arguments can be in one of two forms
@@ -312,6 +715,12 @@ func (r *APIConnection) Get(endpoint string, qparams ...string) ([]byte, error)
 // of the use cases (where we're just passing key, value string pairs) but that
 // remaining 10% we need to pass something more complex
 func (r *APIConnection) Put(endpoint string, sensitive bool, bodyp ...interface{}) ([]byte, error) {
+	return r.PutCtx(context.Background(), endpoint, sensitive, bodyp...)
+}
+
+// PutCtx is Put, but aborts the request (and the read of its response body)
+// as soon as ctx is done.
+func (r *APIConnection) PutCtx(ctx context.Context, endpoint string, sensitive bool, bodyp ...interface{}) ([]byte, error) {
 	params, err := parseParams(bodyp...)
 	if err != nil {
 		return []byte(""), err
@@ -320,7 +729,7 @@ func (r *APIConnection) Put(endpoint string, sensitive bool, bodyp ...interface{
 	if err != nil {
 		return []byte(""), err
 	}
-	return r.doRequest("put", endpoint, body, nil, sensitive, false)
+	return r.doRequest(ctx, "put", endpoint, body, nil, sensitive)
 }
 
 // bodyp arguments can be in one of two forms
@@ -338,6 +747,12 @@ func (r *APIConnection) Put(endpoint string, sensitive bool, bodyp ...interface{
 // of the use cases (where we're just passing key, value string pairs) but that
 // remaining 10% we need to pass something more complex
 func (r *APIConnection) Post(endpoint string, bodyp ...interface{}) ([]byte, error) {
+	return r.PostCtx(context.Background(), endpoint, bodyp...)
+}
+
+// PostCtx is Post, but aborts the request (and the read of its response
+// body) as soon as ctx is done.
+func (r *APIConnection) PostCtx(ctx context.Context, endpoint string, bodyp ...interface{}) ([]byte, error) {
 	params, err := parseParams(bodyp...)
 	if err != nil {
 		return []byte(""), err
@@ -346,7 +761,7 @@ func (r *APIConnection) Post(endpoint string, bodyp ...interface{}) ([]byte, err
 	if err != nil {
 		return []byte(""), err
 	}
-	return r.doRequest("post", endpoint, body, nil, false, false)
+	return r.doRequest(ctx, "post", endpoint, body, nil, false)
 }
 
 // bodyp arguments can be in one of two forms
@@ -364,6 +779,12 @@ func (r *APIConnection) Post(endpoint string, bodyp ...interface{}) ([]byte, err
 // of the use cases (where we're just passing key, value string pairs) but that
 // remaining 10% we need to pass something more complex
 func (r *APIConnection) Delete(endpoint string, bodyp ...interface{}) ([]byte, error) {
+	return r.DeleteCtx(context.Background(), endpoint, bodyp...)
+}
+
+// DeleteCtx is Delete, but aborts the request (and the read of its response
+// body) as soon as ctx is done.
+func (r *APIConnection) DeleteCtx(ctx context.Context, endpoint string, bodyp ...interface{}) ([]byte, error) {
 	params, err := parseParams(bodyp...)
 	if err != nil {
 		return []byte(""), err
@@ -372,7 +793,7 @@ func (r *APIConnection) Delete(endpoint string, bodyp ...interface{}) ([]byte, e
 	if err != nil {
 		return []byte(""), err
 	}
-	return r.doRequest("delete", endpoint, body, nil, false, false)
+	return r.doRequest(ctx, "delete", endpoint, body, nil, false)
 }
 
 // After successful login the API token is saved in the APIConnection object
@@ -414,28 +835,14 @@ func getData(resp []byte) (json.RawMessage, *ErrResponse21, error) {
 	return r.DataRaw, &e, nil
 }
 
-func handleBadResponse(resp *http.Response) error {
-	_, ok := httpErrors[resp.StatusCode]
-	if resp.StatusCode == 401 {
-		var e ErrResponse21
-		b, err := ioutil.ReadAll(resp.Body)
-		if err != nil {
-			log.Errorf("Bad Response: %#v", resp)
-			panic("Couldn't understand response")
-		}
-		err = json.Unmarshal(b, &e)
-		if err != nil {
-			log.Errorf("Bad Response: %#v", resp)
-			panic("Couldn't understand response")
-		}
-		if e.Name == permDeniedError {
-			return Retry
-		}
-	}
-	if ok {
-		return fmt.Errorf("%s", resp.Status)
-	}
-	return nil
+// handleBadResponse classifies status into a *statusError for any status of
+// 400 or above, and returns nil for anything lower. Which of those are worth retrying is now a RetryPolicy decision
+// made by the caller, not something this function special-cases.
+func handleBadResponse(status int) error {
+	if status < 400 {
+		return nil
+	}
+	return &statusError{Code: status, Status: fmt.Sprintf("%d %s", status, http.StatusText(status))}
 }
 
 func parseParams(params ...interface{}) (map[string]interface{}, error) {
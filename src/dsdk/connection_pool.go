@@ -0,0 +1,233 @@
+package dsdk
+
+import (
+	"context"
+	"fmt"
+	"github.com/Datera/go-sdk/src/dsdk/metrics"
+	log "github.com/Sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
+	"sync/atomic"
+	"time"
+)
+
+// defaultRevalidateBackoff is how long a quarantined connection waits
+// before the pool attempts to log it back in.
+const defaultRevalidateBackoff = 30 * time.Second
+
+// ConnectionPool hands out one of several independent APIConnections so
+// callers can parallelize requests instead of serializing everything on a
+// single APIConnection's Mutex. Connections that repeatedly fail are
+// quarantined out of rotation and periodically revalidated in the
+// background.
+type ConnectionPool struct {
+	conns    []*APIConnection
+	next     uint64
+	backoff  time.Duration
+	stopCh   chan struct{}
+	stopOnce int32
+}
+
+// PoolOption configures every APIConnection a ConnectionPool creates. Use it
+// to attach the things NewAPIConnection itself has no parameters for: a
+// shared metrics.Collector, a TracerProvider, a custom Transport, or a
+// non-default RetryPolicy.
+type PoolOption func(*APIConnection)
+
+// WithPoolMetrics attaches m to every connection in the pool.
+func WithPoolMetrics(m *metrics.Collector) PoolOption {
+	return func(c *APIConnection) { c.Metrics = m }
+}
+
+// WithPoolTracerProvider attaches tp to every connection in the pool.
+func WithPoolTracerProvider(tp trace.TracerProvider) PoolOption {
+	return func(c *APIConnection) { c.TracerProvider = tp }
+}
+
+// WithPoolTransport attaches t to every connection in the pool.
+func WithPoolTransport(t Transport) PoolOption {
+	return func(c *APIConnection) { c.Transport = t }
+}
+
+// WithPoolRetryPolicy attaches p to every connection in the pool.
+func WithPoolRetryPolicy(p *RetryPolicy) PoolOption {
+	return func(c *APIConnection) { c.RetryPolicy = p }
+}
+
+// NewConnectionPool builds a ConnectionPool of size independent
+// APIConnections sharing the given credentials, each with its own Mutex,
+// http.Client, and API token. opts are applied to every connection in the
+// pool, so features configured per-APIConnection (Metrics, TracerProvider,
+// Transport, RetryPolicy) still work when calling through the pool. A
+// background goroutine periodically attempts to revalidate any connection
+// the pool has quarantined.
+func NewConnectionPool(hostname, port, username, password, apiVersion, tenant, timeout string, headers map[string]string, secure bool, size int, opts ...PoolOption) (*ConnectionPool, error) {
+	if size < 1 {
+		size = 1
+	}
+	conns := make([]*APIConnection, 0, size)
+	for i := 0; i < size; i++ {
+		c, err := NewAPIConnection(hostname, port, username, password, apiVersion, tenant, timeout, headers, secure)
+		if err != nil {
+			return nil, err
+		}
+		conn := c.(*APIConnection)
+		for _, opt := range opts {
+			opt(conn)
+		}
+		conns = append(conns, conn)
+	}
+	p := &ConnectionPool{
+		conns:   conns,
+		backoff: defaultRevalidateBackoff,
+		stopCh:  make(chan struct{}),
+	}
+	go p.revalidateLoop()
+	return p, nil
+}
+
+// Close stops the pool's background revalidator. It does not close the
+// underlying connections' http.Clients.
+func (p *ConnectionPool) Close() {
+	if atomic.CompareAndSwapInt32(&p.stopOnce, 0, 1) {
+		close(p.stopCh)
+	}
+}
+
+// pick returns the least-busy non-quarantined connection, starting the scan
+// from the next round-robin position so equally-busy connections still get
+// rotated evenly.
+func (p *ConnectionPool) pick() (*APIConnection, error) {
+	n := len(p.conns)
+	if n == 0 {
+		return nil, fmt.Errorf("connection pool has no connections")
+	}
+	start := int(atomic.AddUint64(&p.next, 1) % uint64(n))
+	var best *APIConnection
+	bestLoad := int32(-1)
+	for i := 0; i < n; i++ {
+		c := p.conns[(start+i)%n]
+		if atomic.LoadInt32(&c.Quarantined) == 1 {
+			continue
+		}
+		load := atomic.LoadInt32(&c.InFlight)
+		if bestLoad == -1 || load < bestLoad {
+			best, bestLoad = c, load
+		}
+	}
+	if best == nil {
+		return nil, fmt.Errorf("no healthy connections available in pool")
+	}
+	return best, nil
+}
+
+// call picks a connection, tracks its in-flight count around fn, and
+// records the result for quarantine purposes.
+func (p *ConnectionPool) call(fn func(*APIConnection) ([]byte, error)) ([]byte, error) {
+	c, err := p.pick()
+	if err != nil {
+		return []byte(""), err
+	}
+	atomic.AddInt32(&c.InFlight, 1)
+	defer atomic.AddInt32(&c.InFlight, -1)
+	return fn(c)
+}
+
+func (p *ConnectionPool) Get(endpoint string, qparams ...string) ([]byte, error) {
+	return p.call(func(c *APIConnection) ([]byte, error) {
+		return c.Get(endpoint, qparams...)
+	})
+}
+
+// GetCtx is Get, but aborts the request as soon as ctx is done.
+func (p *ConnectionPool) GetCtx(ctx context.Context, endpoint string, qparams ...string) ([]byte, error) {
+	return p.call(func(c *APIConnection) ([]byte, error) {
+		return c.GetCtx(ctx, endpoint, qparams...)
+	})
+}
+
+func (p *ConnectionPool) Put(endpoint string, sensitive bool, bodyp ...interface{}) ([]byte, error) {
+	return p.call(func(c *APIConnection) ([]byte, error) {
+		return c.Put(endpoint, sensitive, bodyp...)
+	})
+}
+
+// PutCtx is Put, but aborts the request as soon as ctx is done.
+func (p *ConnectionPool) PutCtx(ctx context.Context, endpoint string, sensitive bool, bodyp ...interface{}) ([]byte, error) {
+	return p.call(func(c *APIConnection) ([]byte, error) {
+		return c.PutCtx(ctx, endpoint, sensitive, bodyp...)
+	})
+}
+
+func (p *ConnectionPool) Post(endpoint string, bodyp ...interface{}) ([]byte, error) {
+	return p.call(func(c *APIConnection) ([]byte, error) {
+		return c.Post(endpoint, bodyp...)
+	})
+}
+
+// PostCtx is Post, but aborts the request as soon as ctx is done.
+func (p *ConnectionPool) PostCtx(ctx context.Context, endpoint string, bodyp ...interface{}) ([]byte, error) {
+	return p.call(func(c *APIConnection) ([]byte, error) {
+		return c.PostCtx(ctx, endpoint, bodyp...)
+	})
+}
+
+func (p *ConnectionPool) Delete(endpoint string, bodyp ...interface{}) ([]byte, error) {
+	return p.call(func(c *APIConnection) ([]byte, error) {
+		return c.Delete(endpoint, bodyp...)
+	})
+}
+
+// DeleteCtx is Delete, but aborts the request as soon as ctx is done.
+func (p *ConnectionPool) DeleteCtx(ctx context.Context, endpoint string, bodyp ...interface{}) ([]byte, error) {
+	return p.call(func(c *APIConnection) ([]byte, error) {
+		return c.DeleteCtx(ctx, endpoint, bodyp...)
+	})
+}
+
+// Login logs in every connection in the pool that doesn't already have a
+// token, returning the first error encountered.
+func (p *ConnectionPool) Login() error {
+	for _, c := range p.conns {
+		if err := c.Login(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// revalidateLoop periodically re-logs-in quarantined connections after
+// backoff has elapsed, returning them to rotation on success.
+func (p *ConnectionPool) revalidateLoop() {
+	ticker := time.NewTicker(p.backoff)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.stopCh:
+			return
+		case <-ticker.C:
+			p.revalidateQuarantined()
+		}
+	}
+}
+
+func (p *ConnectionPool) revalidateQuarantined() {
+	for _, c := range p.conns {
+		if atomic.LoadInt32(&c.Quarantined) != 1 {
+			continue
+		}
+		c.Mutex.Lock()
+		quarantinedAt := c.QuarantinedAt
+		c.Mutex.Unlock()
+		if time.Since(quarantinedAt) < p.backoff {
+			continue
+		}
+		c.APIToken = USetToken
+		if err := c.Login(); err != nil {
+			log.Debugf("Revalidation failed for connection %s:%s: %s", c.Hostname, c.Port, err)
+			continue
+		}
+		atomic.StoreInt32(&c.FailureCount, 0)
+		atomic.StoreInt32(&c.Quarantined, 0)
+		log.Infof("Connection %s:%s returned to rotation after revalidation", c.Hostname, c.Port)
+	}
+}
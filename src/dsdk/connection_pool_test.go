@@ -0,0 +1,90 @@
+package dsdk
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func newTestPool(t *testing.T, n int) *ConnectionPool {
+	t.Helper()
+	conns := make([]*APIConnection, n)
+	for i := range conns {
+		conns[i] = newTestConnection(t, &FakeTransport{
+			Responses: []FakeResponse{{Status: 200, Body: []byte(`{"key": "faketoken"}`)}},
+		})
+	}
+	return &ConnectionPool{
+		conns:   conns,
+		backoff: time.Minute,
+		stopCh:  make(chan struct{}),
+	}
+}
+
+func TestConnectionPoolPickSkipsQuarantinedAndRotates(t *testing.T) {
+	p := newTestPool(t, 3)
+	atomic.StoreInt32(&p.conns[1].Quarantined, 1)
+
+	seen := map[*APIConnection]bool{}
+	for i := 0; i < 6; i++ {
+		c, err := p.pick()
+		if err != nil {
+			t.Fatalf("pick: %s", err)
+		}
+		if c == p.conns[1] {
+			t.Fatal("pick returned a quarantined connection")
+		}
+		seen[c] = true
+	}
+	if len(seen) != 2 {
+		t.Errorf("expected pick to rotate across the 2 healthy connections, only saw %d", len(seen))
+	}
+}
+
+func TestConnectionPoolPickAllQuarantined(t *testing.T) {
+	p := newTestPool(t, 2)
+	atomic.StoreInt32(&p.conns[0].Quarantined, 1)
+	atomic.StoreInt32(&p.conns[1].Quarantined, 1)
+
+	if _, err := p.pick(); err == nil {
+		t.Fatal("expected an error when every connection is quarantined, got nil")
+	}
+}
+
+func TestConnectionPoolRevalidateReturnsConnectionToRotation(t *testing.T) {
+	p := newTestPool(t, 1)
+	c := p.conns[0]
+	c.APIToken = ""
+	atomic.StoreInt32(&c.Quarantined, 1)
+	atomic.StoreInt32(&c.FailureCount, quarantineThreshold)
+	c.Mutex.Lock()
+	c.QuarantinedAt = time.Now().Add(-2 * p.backoff)
+	c.Mutex.Unlock()
+
+	p.revalidateQuarantined()
+
+	if atomic.LoadInt32(&c.Quarantined) != 0 {
+		t.Error("expected connection to leave quarantine after a successful revalidation")
+	}
+	if atomic.LoadInt32(&c.FailureCount) != 0 {
+		t.Error("expected FailureCount to reset after a successful revalidation")
+	}
+	if _, err := p.pick(); err != nil {
+		t.Errorf("pick after revalidation: %s", err)
+	}
+}
+
+func TestConnectionPoolRevalidateSkipsBeforeBackoffElapses(t *testing.T) {
+	p := newTestPool(t, 1)
+	c := p.conns[0]
+	atomic.StoreInt32(&c.Quarantined, 1)
+	c.Mutex.Lock()
+	c.QuarantinedAt = time.Now()
+	c.Mutex.Unlock()
+
+	p.revalidateQuarantined()
+
+	if atomic.LoadInt32(&c.Quarantined) != 1 {
+		t.Error("expected connection to remain quarantined before backoff elapses")
+	}
+}
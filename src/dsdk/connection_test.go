@@ -0,0 +1,85 @@
+package dsdk
+
+import (
+	"context"
+	"net/http"
+	"testing"
+)
+
+func newTestConnection(t *testing.T, transport Transport) *APIConnection {
+	t.Helper()
+	c, err := NewAPIConnection("example.com", "7717", "user", "pass", "v2", "/root", "5s", nil, true)
+	if err != nil {
+		t.Fatalf("NewAPIConnection: %s", err)
+	}
+	conn := c.(*APIConnection)
+	conn.Transport = transport
+	conn.APIToken = "faketoken"
+	return conn
+}
+
+func TestDoRequestRetriesOnServiceUnavailableThenSucceeds(t *testing.T) {
+	transport := &FakeTransport{
+		Responses: []FakeResponse{
+			{Status: http.StatusServiceUnavailable, Body: []byte(`{}`)},
+			{Status: http.StatusOK, Body: []byte(`{}`)},
+		},
+	}
+	conn := newTestConnection(t, transport)
+	conn.RetryPolicy.InitialBackoff = 0
+	conn.RetryPolicy.MaxBackoff = 0
+
+	body, status, err := conn.doRequestStatus(context.Background(), "get", "app_instances", nil, nil, false)
+	if err != nil {
+		t.Fatalf("doRequestStatus: %s", err)
+	}
+	if status != http.StatusOK {
+		t.Errorf("expected status %d, got %d", http.StatusOK, status)
+	}
+	if string(body) != "{}" {
+		t.Errorf("expected body %q, got %q", "{}", body)
+	}
+	if got := transport.callCount(); got != 2 {
+		t.Errorf("expected 2 attempts, got %d", got)
+	}
+}
+
+func TestDoRequestGivesUpAfterMaxAttempts(t *testing.T) {
+	transport := &FakeTransport{
+		Responses: []FakeResponse{
+			{Status: http.StatusServiceUnavailable, Body: []byte(`{}`)},
+		},
+	}
+	conn := newTestConnection(t, transport)
+	conn.RetryPolicy.MaxAttempts = 2
+	conn.RetryPolicy.InitialBackoff = 0
+	conn.RetryPolicy.MaxBackoff = 0
+
+	_, status, err := conn.doRequestStatus(context.Background(), "get", "app_instances", nil, nil, false)
+	if err == nil {
+		t.Fatal("expected an error, got nil")
+	}
+	if status != http.StatusServiceUnavailable {
+		t.Errorf("expected status %d, got %d", http.StatusServiceUnavailable, status)
+	}
+	if got := transport.callCount(); got != 2 {
+		t.Errorf("expected 2 attempts, got %d", got)
+	}
+}
+
+func TestDoRequestSurfacesRealStatusOnSuccess(t *testing.T) {
+	transport := &FakeTransport{
+		Responses: []FakeResponse{
+			{Status: http.StatusAccepted, Body: []byte(`{}`)},
+		},
+	}
+	conn := newTestConnection(t, transport)
+
+	_, status, err := conn.doRequestStatus(context.Background(), "post", "app_instances", nil, nil, false)
+	if err != nil {
+		t.Fatalf("doRequestStatus: %s", err)
+	}
+	if status != http.StatusAccepted {
+		t.Errorf("expected status %d, got %d", http.StatusAccepted, status)
+	}
+}
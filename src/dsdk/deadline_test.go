@@ -0,0 +1,84 @@
+package dsdk
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDoRequestAbortsOnContextCancel(t *testing.T) {
+	transport := &FakeTransport{BlockUntilCtxDone: true}
+	conn := newTestConnection(t, transport)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+
+	start := time.Now()
+	_, _, err := conn.doRequestStatus(ctx, "get", "app_instances", nil, nil, false)
+	elapsed := time.Since(start)
+
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	if elapsed > time.Second {
+		t.Fatalf("doRequestStatus took %s to observe cancellation, expected well under a second", elapsed)
+	}
+}
+
+func TestSetReadDeadlineCancelsInFlightCall(t *testing.T) {
+	transport := &FakeTransport{BlockUntilCtxDone: true}
+	conn := newTestConnection(t, transport)
+
+	done := make(chan error, 1)
+	go func() {
+		_, _, err := conn.doRequestStatus(context.Background(), "get", "app_instances", nil, nil, false)
+		done <- err
+	}()
+
+	// Give the call a moment to start so it picks up the first deadlineCh.
+	time.Sleep(20 * time.Millisecond)
+	conn.SetReadDeadline(time.Now())
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("expected the in-flight call to be cancelled, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("SetReadDeadline did not cancel the in-flight call")
+	}
+}
+
+func TestSetReadDeadlineToSameValueDoesNotCancelInFlightCall(t *testing.T) {
+	transport := &FakeTransport{
+		Responses: []FakeResponse{{Status: 200, Body: []byte(`{}`)}},
+	}
+	conn := newTestConnection(t, transport)
+
+	deadline := time.Now().Add(time.Hour)
+	if err := conn.SetReadDeadline(deadline); err != nil {
+		t.Fatalf("SetReadDeadline: %s", err)
+	}
+
+	// Setting the same deadline again must not swap deadlineCh, or it would
+	// spuriously cancel whatever call is about to run under the old channel.
+	chBefore := conn.deadlineCh
+	if err := conn.SetReadDeadline(deadline); err != nil {
+		t.Fatalf("SetReadDeadline: %s", err)
+	}
+	if conn.deadlineCh != chBefore {
+		t.Fatal("SetReadDeadline swapped deadlineCh even though the deadline value didn't change")
+	}
+
+	_, status, err := conn.doRequestStatus(context.Background(), "get", "app_instances", nil, nil, false)
+	if err != nil {
+		t.Fatalf("doRequestStatus: %s", err)
+	}
+	if status != 200 {
+		t.Errorf("expected status 200, got %d", status)
+	}
+}
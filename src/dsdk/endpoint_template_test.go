@@ -0,0 +1,44 @@
+package dsdk
+
+import "testing"
+
+func TestEndpointTemplate(t *testing.T) {
+	cases := []struct {
+		name     string
+		endpoint string
+		want     string
+	}{
+		{
+			name:     "multi-segment known collections",
+			endpoint: "app_instances/my-ai/storage_instances/si-1",
+			want:     "/app_instances/{name}/storage_instances/{si}",
+		},
+		{
+			name:     "unknown collection name passes through unchanged",
+			endpoint: "some_unknown_thing/abc",
+			want:     "/some_unknown_thing/abc",
+		},
+		{
+			name:     "trailing static segment after a known collection",
+			endpoint: "app_instances/my-ai/storage_instances/si-1/acl_policy",
+			want:     "/app_instances/{name}/storage_instances/{si}/acl_policy",
+		},
+		{
+			name:     "known collection name with nothing following it",
+			endpoint: "app_instances",
+			want:     "/app_instances",
+		},
+		{
+			name:     "leading and trailing slashes are trimmed",
+			endpoint: "/app_instances/my-ai/",
+			want:     "/app_instances/{name}",
+		},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			if got := endpointTemplate(c.endpoint); got != c.want {
+				t.Errorf("endpointTemplate(%q) = %q, want %q", c.endpoint, got, c.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package dsdk
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+// FakeResponse is a single canned response for FakeTransport to hand back.
+type FakeResponse struct {
+	Status  int
+	Headers http.Header
+	Body    []byte
+	Err     error
+}
+
+// FakeTransport is a Transport that returns a scripted sequence of
+// FakeResponses instead of talking to a real server, letting tests exercise
+// APIConnection's retry, backoff, and status-handling logic without an HTTP
+// server. Responses are handed out in order; once exhausted, the last one is
+// repeated for any further calls. If BlockUntilCtxDone is set, RoundTrip
+// ignores Responses and instead blocks until ctx is done, returning
+// ctx.Err(), to simulate a server that never answers.
+type FakeTransport struct {
+	Responses         []FakeResponse
+	BlockUntilCtxDone bool
+
+	mu       sync.Mutex
+	Requests []FakeRequest
+}
+
+// FakeRequest records the arguments of one RoundTrip call, so a test can
+// assert on what APIConnection actually sent.
+type FakeRequest struct {
+	Method  string
+	URL     string
+	Headers map[string]string
+	Body    []byte
+}
+
+// RoundTrip implements Transport.
+func (t *FakeTransport) RoundTrip(ctx context.Context, method, url string, headers map[string]string, body []byte) (int, http.Header, []byte, error) {
+	t.mu.Lock()
+	t.Requests = append(t.Requests, FakeRequest{Method: method, URL: url, Headers: headers, Body: body})
+	block := t.BlockUntilCtxDone
+	t.mu.Unlock()
+
+	if block {
+		<-ctx.Done()
+		return 0, nil, nil, ctx.Err()
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if len(t.Responses) == 0 {
+		return 0, nil, nil, nil
+	}
+	i := len(t.Requests) - 1
+	if i >= len(t.Responses) {
+		i = len(t.Responses) - 1
+	}
+	r := t.Responses[i]
+	return r.Status, r.Headers, r.Body, r.Err
+}
+
+// callCount returns the number of RoundTrip calls made so far.
+func (t *FakeTransport) callCount() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.Requests)
+}
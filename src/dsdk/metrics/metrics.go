@@ -0,0 +1,61 @@
+// Package metrics provides the Prometheus metrics recorded for every call
+// an APIConnection makes against a Datera cluster.
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Collector bundles the metrics recorded for Datera API calls. Build one
+// with NewCollector and assign it to APIConnection.Metrics; a nil Collector
+// disables metrics entirely.
+type Collector struct {
+	RequestDuration   *prometheus.HistogramVec
+	RequestBodyBytes  *prometheus.HistogramVec
+	ResponseBodyBytes *prometheus.HistogramVec
+	RequestsInFlight  prometheus.Gauge
+	RetriesTotal      *prometheus.CounterVec
+}
+
+// NewCollector builds a Collector and registers its metrics with reg (e.g.
+// prometheus.DefaultRegisterer). Endpoint labels must already be
+// templatized by the caller (e.g. "/app_instances/{name}") so cardinality
+// stays bounded.
+func NewCollector(reg prometheus.Registerer) (*Collector, error) {
+	c := &Collector{
+		RequestDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name: "datera_request_duration_seconds",
+			Help: "Duration of Datera API requests, in seconds.",
+		}, []string{"method", "endpoint", "status"}),
+		RequestBodyBytes: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "datera_request_body_bytes",
+			Help:    "Size of Datera API request bodies, in bytes.",
+			Buckets: prometheus.ExponentialBuckets(64, 4, 8),
+		}, []string{"method", "endpoint"}),
+		ResponseBodyBytes: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "datera_response_body_bytes",
+			Help:    "Size of Datera API response bodies, in bytes.",
+			Buckets: prometheus.ExponentialBuckets(64, 4, 8),
+		}, []string{"method", "endpoint"}),
+		RequestsInFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "datera_requests_in_flight",
+			Help: "Number of Datera API requests currently outstanding.",
+		}),
+		RetriesTotal: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "datera_retries_total",
+			Help: "Count of Datera API request retries, by method and endpoint.",
+		}, []string{"method", "endpoint"}),
+	}
+	for _, coll := range []prometheus.Collector{
+		c.RequestDuration,
+		c.RequestBodyBytes,
+		c.ResponseBodyBytes,
+		c.RequestsInFlight,
+		c.RetriesTotal,
+	} {
+		if err := reg.Register(coll); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
@@ -0,0 +1,39 @@
+package metrics
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestNewCollectorRegistersAllMetrics(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	c, err := NewCollector(reg)
+	if err != nil {
+		t.Fatalf("NewCollector: %s", err)
+	}
+
+	c.RequestDuration.WithLabelValues("get", "/app_instances/{name}", "200").Observe(0.1)
+	c.RequestBodyBytes.WithLabelValues("get", "/app_instances/{name}").Observe(128)
+	c.ResponseBodyBytes.WithLabelValues("get", "/app_instances/{name}").Observe(256)
+	c.RequestsInFlight.Inc()
+	c.RetriesTotal.WithLabelValues("get", "/app_instances/{name}").Inc()
+
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatalf("Gather: %s", err)
+	}
+	if len(families) != 5 {
+		t.Errorf("expected 5 registered metric families, got %d", len(families))
+	}
+}
+
+func TestNewCollectorRejectsDuplicateRegistration(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	if _, err := NewCollector(reg); err != nil {
+		t.Fatalf("NewCollector: %s", err)
+	}
+	if _, err := NewCollector(reg); err == nil {
+		t.Fatal("expected registering a second Collector against the same Registerer to fail")
+	}
+}
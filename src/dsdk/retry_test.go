@@ -0,0 +1,46 @@
+package dsdk
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestRetryPolicyShouldRetry(t *testing.T) {
+	p := defaultRetryPolicy()
+
+	if p.shouldRetry(nil) {
+		t.Error("nil error should never be retried")
+	}
+	if !p.shouldRetry(&statusError{Code: http.StatusServiceUnavailable}) {
+		t.Error("503 should be retryable under the default policy")
+	}
+	if p.shouldRetry(&statusError{Code: http.StatusNotFound}) {
+		t.Error("404 should not be retryable under the default policy")
+	}
+	if !p.shouldRetry(&net.DNSError{}) {
+		t.Error("a net.Error should be retryable under the default policy")
+	}
+	if p.shouldRetry(errors.New("boom")) {
+		t.Error("a plain error should not be retryable under the default policy")
+	}
+}
+
+func TestRetryPolicyBackoffRespectsCeiling(t *testing.T) {
+	p := &RetryPolicy{InitialBackoff: 100 * time.Millisecond, MaxBackoff: 250 * time.Millisecond}
+	for attempt := 1; attempt <= 5; attempt++ {
+		d := p.backoff(attempt)
+		if d < 0 || d > p.MaxBackoff {
+			t.Errorf("attempt %d: backoff %s outside [0, %s]", attempt, d, p.MaxBackoff)
+		}
+	}
+}
+
+func TestRetryPolicyBackoffZeroWhenUnconfigured(t *testing.T) {
+	p := &RetryPolicy{}
+	if d := p.backoff(1); d != 0 {
+		t.Errorf("expected 0 backoff with no InitialBackoff/MaxBackoff, got %s", d)
+	}
+}
@@ -0,0 +1,31 @@
+package dsdk
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestStartAndEndSpanWithNoTracerProviderIsANoop(t *testing.T) {
+	conn := newTestConnection(t, &FakeTransport{})
+
+	ctx, span := conn.startSpan(context.Background(), "get", "/app_instances/{name}")
+	if ctx == nil {
+		t.Fatal("startSpan returned a nil context")
+	}
+	conn.endSpan(span, http.StatusOK, "req-uuid", nil)
+}
+
+func TestStartAndEndSpanWithTracerProvider(t *testing.T) {
+	conn := newTestConnection(t, &FakeTransport{})
+	conn.TracerProvider = trace.NewNoopTracerProvider()
+
+	ctx, span := conn.startSpan(context.Background(), "get", "/app_instances/{name}")
+	if ctx == nil {
+		t.Fatal("startSpan returned a nil context")
+	}
+	conn.endSpan(span, http.StatusInternalServerError, "req-uuid", errors.New("boom"))
+}
@@ -0,0 +1,82 @@
+package dsdk
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net"
+	"net/http"
+)
+
+// Transport performs the wire exchange for a single Datera API call.
+// APIConnection owns everything above the wire (Login, retries, headers,
+// query params); Transport only has to get bytes to the server and back,
+// which makes APIConnection testable without an HTTP server (supply a fake
+// Transport that returns canned Response21/ErrResponse21 JSON) and opens
+// the door to non-HTTP transports.
+type Transport interface {
+	RoundTrip(ctx context.Context, method, url string, headers map[string]string, body []byte) (status int, respHeaders http.Header, respBody []byte, err error)
+}
+
+// HTTPTransport is the default Transport, wrapping an *http.Client.
+type HTTPTransport struct {
+	Client *http.Client
+}
+
+// NewHTTPTransport builds an HTTPTransport around client.
+func NewHTTPTransport(client *http.Client) *HTTPTransport {
+	return &HTTPTransport{Client: client}
+}
+
+func (t *HTTPTransport) RoundTrip(ctx context.Context, method, url string, headers map[string]string, body []byte) (int, http.Header, []byte, error) {
+	var b io.Reader
+	if body != nil {
+		b = bytes.NewReader(body)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, b)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	for h, v := range headers {
+		req.Header.Set(h, v)
+	}
+	resp, err := t.Client.Do(req)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	defer resp.Body.Close()
+	rbody, err := readAllCtx(ctx, resp.Body)
+	if err != nil {
+		return resp.StatusCode, resp.Header, nil, err
+	}
+	return resp.StatusCode, resp.Header, rbody, nil
+}
+
+// UnixSocketTransport talks to a local Datera agent over a Unix domain
+// socket instead of a network address. url still needs a well-formed
+// "http://" (or "https://") form for http.NewRequestWithContext to parse;
+// only the actual dial is redirected to SocketPath. It embeds HTTPTransport
+// and only supplies the *http.Client's DialContext, since the round trip
+// itself is identical once the client is wired up to dial the socket.
+type UnixSocketTransport struct {
+	HTTPTransport
+	SocketPath string
+}
+
+// NewUnixSocketTransport builds an UnixSocketTransport that dials
+// socketPath for every request.
+func NewUnixSocketTransport(socketPath string) *UnixSocketTransport {
+	return &UnixSocketTransport{
+		SocketPath: socketPath,
+		HTTPTransport: HTTPTransport{
+			Client: &http.Client{
+				Transport: &http.Transport{
+					DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+						var d net.Dialer
+						return d.DialContext(ctx, "unix", socketPath)
+					},
+				},
+			},
+		},
+	}
+}